@@ -0,0 +1,37 @@
+package verify
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var registryURL string
+
+// VerifyCmd checks that a pushed manifest has a valid signature on record,
+// by asking the registry to verify it (?verify=true) rather than fetching
+// and checking keys locally.
+var VerifyCmd = &cobra.Command{
+	Use:   "verify <name> <reference>",
+	Short: "Verify a manifest's signature against the registry's trust store",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, reference := args[0], args[1]
+		url := fmt.Sprintf("%s/v2/%s/manifests/%s?verify=true", registryURL, name, reference)
+		resp, err := http.Get(url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s:%s is not verified (registry returned %s)", name, reference, resp.Status)
+		}
+		fmt.Printf("%s:%s is signed by a trusted key\n", name, reference)
+		return nil
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().StringVar(&registryURL, "registry", "http://localhost:8080", "base URL of the modelx registry")
+}
@@ -0,0 +1,73 @@
+// Package auth implements the Docker/OCI distribution bearer token
+// authentication and authorization protocol: requests carry a JWT in the
+// Authorization header, and a pluggable Authorizer checks it grants the
+// scope needed for the operation being performed.
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// Action is one of the scope actions understood by the registry.
+type Action string
+
+const (
+	ActionPull   Action = "pull"
+	ActionPush   Action = "push"
+	ActionDelete Action = "delete"
+	ActionAll    Action = "*"
+)
+
+// Scope describes the access a request needs, following the
+// `repository:<name>:<actions>` grammar used by Docker's token-auth spec.
+type Scope struct {
+	Type    string
+	Name    string
+	Actions []Action
+}
+
+// String renders the scope in the `type:name:action,action` form used in
+// both the WWW-Authenticate challenge and the JWT `access` claim.
+func (s Scope) String() string {
+	actions := ""
+	for i, a := range s.Actions {
+		if i > 0 {
+			actions += ","
+		}
+		actions += string(a)
+	}
+	return fmt.Sprintf("%s:%s:%s", s.Type, s.Name, actions)
+}
+
+// Authorizer decides whether a bearer token grants the given scope.
+type Authorizer interface {
+	// Authorize returns nil if token grants every action in scope, or an
+	// error (typically ErrUnauthorized) otherwise.
+	Authorize(ctx context.Context, token string, scope Scope) error
+}
+
+// ErrUnauthorized is returned by an Authorizer when the token is missing,
+// invalid, or does not grant the requested scope.
+type ErrUnauthorized struct {
+	Reason string
+}
+
+func (e *ErrUnauthorized) Error() string {
+	return fmt.Sprintf("unauthorized: %s", e.Reason)
+}
+
+// ActionForMethod maps an HTTP method against the registry's repository
+// routes to the scope action it requires.
+func ActionForMethod(method string) Action {
+	switch method {
+	case "GET", "HEAD":
+		return ActionPull
+	case "POST", "PATCH", "PUT":
+		return ActionPush
+	case "DELETE":
+		return ActionDelete
+	default:
+		return ActionAll
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserPolicy is one user's credential plus the scopes they are allowed to
+// request tokens for. Requested actions that aren't covered here are dropped
+// before the token is signed, not granted; a requested scope with none of its
+// actions covered is left out of the token entirely.
+type UserPolicy struct {
+	// PasswordHash is a bcrypt hash, as found in an htpasswd file's second
+	// column.
+	PasswordHash []byte
+	// Scopes lists the repository:name:actions this user may be issued a
+	// token for. A Scope with ActionAll in its Actions grants every action
+	// on that repository.
+	Scopes []Scope
+}
+
+// HtpasswdAuthServer is a single-node token issuer backed by an htpasswd
+// file: clients exchange a username/password for a self-signed JWT that the
+// registry's own JWTAuthorizer will then accept. Intended for small
+// deployments that don't want to stand up a separate auth service.
+type HtpasswdAuthServer struct {
+	Issuer   string
+	Service  string
+	SignKey  []byte
+	TokenTTL time.Duration
+	// Users maps username to its password hash and allowed scopes.
+	Users map[string]UserPolicy
+}
+
+func (h *HtpasswdAuthServer) ttl() time.Duration {
+	if h.TokenTTL <= 0 {
+		return time.Minute * 5
+	}
+	return h.TokenTTL
+}
+
+// ServeHTTP implements the token endpoint referenced by the WWW-Authenticate
+// challenge: GET /token?service=<svc>&scope=<repository:name:actions>.
+func (h *HtpasswdAuthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	policy, authenticated := h.checkPassword(username, password)
+	if !ok || !authenticated {
+		w.Header().Set("WWW-Authenticate", `Basic realm="modelx"`)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	scopeStr := r.URL.Query().Get("scope")
+	access := []accessEntry{}
+	if scopeStr != "" {
+		granted := intersectScope(parseRequestedScope(scopeStr), policy.Scopes)
+		if len(granted.Actions) > 0 {
+			access = append(access, granted)
+		}
+	}
+	now := time.Now()
+	claims := registryClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    h.Issuer,
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(h.ttl())),
+		},
+		Access: access,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(h.SignKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":      signed,
+		"expires_in": int(h.ttl().Seconds()),
+		"issued_at":  now.Format(time.RFC3339),
+	})
+}
+
+func (h *HtpasswdAuthServer) checkPassword(username, password string) (UserPolicy, bool) {
+	policy, ok := h.Users[username]
+	if !ok {
+		return UserPolicy{}, false
+	}
+	if bcrypt.CompareHashAndPassword(policy.PasswordHash, []byte(password)) != nil {
+		return UserPolicy{}, false
+	}
+	return policy, true
+}
+
+// intersectScope narrows requested down to only the actions that one of
+// allowed's scopes actually grants for the same type:name, so a client can't
+// get more than their policy permits just by asking for it in ?scope=.
+func intersectScope(requested accessEntry, allowed []Scope) accessEntry {
+	granted := accessEntry{Type: requested.Type, Name: requested.Name}
+	for _, scope := range allowed {
+		if scope.Type != requested.Type || scope.Name != requested.Name {
+			continue
+		}
+		has := map[string]bool{}
+		for _, a := range scope.Actions {
+			has[string(a)] = true
+		}
+		for _, want := range requested.Actions {
+			if has[string(ActionAll)] || has[want] {
+				granted.Actions = append(granted.Actions, want)
+			}
+		}
+		break
+	}
+	return granted
+}
+
+// parseRequestedScope parses a single `type:name:actions` scope string, as
+// sent by clients in the ?scope= query parameter.
+func parseRequestedScope(raw string) accessEntry {
+	parts := splitN3(raw)
+	entry := accessEntry{Type: parts[0], Name: parts[1]}
+	if parts[2] != "" {
+		entry.Actions = splitComma(parts[2])
+	}
+	return entry
+}
+
+func splitN3(s string) [3]string {
+	var out [3]string
+	idx := 0
+	start := 0
+	for i := 0; i < len(s) && idx < 2; i++ {
+		if s[i] == ':' {
+			out[idx] = s[start:i]
+			idx++
+			start = i + 1
+		}
+	}
+	out[idx] = s[start:]
+	return out
+}
+
+func splitComma(s string) []string {
+	var out []string
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == ',' {
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// accessEntry mirrors one element of the JWT `access` claim, as defined by
+// the Docker token-auth spec.
+type accessEntry struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+}
+
+type registryClaims struct {
+	jwt.RegisteredClaims
+	Access []accessEntry `json:"access"`
+}
+
+// JWTAuthorizer validates bearer tokens as JWTs issued by a configured
+// issuer, using KeyFunc to resolve the signing key (e.g. from a static
+// secret or a JWKS endpoint), and checks the `access` claim for a matching
+// repository scope.
+type JWTAuthorizer struct {
+	Issuer  string
+	KeyFunc jwt.Keyfunc
+}
+
+func (a *JWTAuthorizer) Authorize(ctx context.Context, token string, scope Scope) error {
+	if token == "" {
+		return &ErrUnauthorized{Reason: "missing bearer token"}
+	}
+	claims := &registryClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, a.KeyFunc, jwt.WithIssuer(a.Issuer))
+	if err != nil || !parsed.Valid {
+		return &ErrUnauthorized{Reason: fmt.Sprintf("invalid token: %v", err)}
+	}
+	for _, entry := range claims.Access {
+		if entry.Type != scope.Type || entry.Name != scope.Name {
+			continue
+		}
+		if grantsAll(entry.Actions, scope.Actions) {
+			return nil
+		}
+	}
+	return &ErrUnauthorized{Reason: fmt.Sprintf("token does not grant scope %q", scope.String())}
+}
+
+func grantsAll(granted []string, wanted []Action) bool {
+	has := map[string]bool{}
+	for _, a := range granted {
+		has[a] = true
+	}
+	for _, w := range wanted {
+		if has[string(ActionAll)] || has[string(w)] {
+			continue
+		}
+		return false
+	}
+	return true
+}
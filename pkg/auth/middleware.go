@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Config describes the realm/service advertised in 401 challenges, as
+// returned to clients so they know where to fetch a token from.
+type Config struct {
+	Realm   string
+	Service string
+}
+
+// Middleware returns a mux middleware that authorizes every request against
+// authorizer before it reaches the registry handlers. The repository name is
+// read from the route's {name} variable; requests without one (the catalog
+// routes) require the wildcard "*" action.
+func Middleware(authorizer Authorizer, cfg Config) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := mux.Vars(r)["name"]
+			action := ActionForMethod(r.Method)
+			if name == "" {
+				name = "catalog"
+				action = ActionAll
+			}
+			scope := Scope{Type: "repository", Name: name, Actions: []Action{action}}
+			token := bearerToken(r)
+			if err := authorizer.Authorize(r.Context(), token, scope); err != nil {
+				challenge(w, cfg, scope)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
+
+func challenge(w http.ResponseWriter, cfg Config, scope Scope) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+		`Bearer realm=%q,service=%q,scope=%q`, cfg.Realm, cfg.Service, scope.String()))
+}
@@ -0,0 +1,96 @@
+// Package metrics registers the Prometheus collectors exported by the
+// registry and a mux middleware that records per-route HTTP metrics while
+// starting an OpenTelemetry trace span for the request.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "modelx_http_requests_total",
+		Help: "Total HTTP requests handled by the registry, by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "modelx_http_request_duration_seconds",
+		Help:    "HTTP request latency by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	BlobBytesTransferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "modelx_blob_bytes_transferred_total",
+		Help: "Total blob bytes transferred, by direction (in/out).",
+	}, []string{"direction"})
+
+	StorageOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "modelx_storage_operation_duration_seconds",
+		Help:    "Latency of storage backend operations, by operation and backend.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op", "backend"})
+
+	ActiveUploadSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "modelx_active_upload_sessions",
+		Help: "Number of currently open chunked blob upload sessions.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		BlobBytesTransferred,
+		StorageOperationDuration,
+		ActiveUploadSessions,
+	)
+}
+
+// Handler serves the registered collectors for GET /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records HTTPRequestsTotal/HTTPRequestDuration for every request
+// and starts a trace span named after the matched route, propagating any
+// incoming traceparent/tracestate into the request context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := StartSpan(r.Context(), r.Header, routeTemplate(r))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		duration := time.Since(start)
+
+		route := routeTemplate(r)
+		HTTPRequestsTotal.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(duration.Seconds())
+	})
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return r.URL.Path
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is used to look up this package's tracer from the global
+// OpenTelemetry tracer provider; callers configure the provider once at
+// startup (e.g. via otel.SetTracerProvider).
+const tracerName = "kubegems.io/modelx/pkg/registry"
+
+// StartSpan extracts any incoming trace context from header and starts a
+// child span named name, returning the derived context to thread through the
+// rest of request handling.
+func StartSpan(ctx context.Context, header http.Header, name string) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// StartChildSpan starts a child span named name under whatever span is
+// already active in ctx (if any), without touching propagation headers.
+// Use this around internal calls, such as RegistryStore operations, that
+// don't cross a network boundary.
+func StartChildSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// InjectHeaders writes the current trace context from ctx into header, for
+// propagation to upstream calls (e.g. the mirror package's RemoteClient).
+func InjectHeaders(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Trace wraps fn in a child span named name and records its duration against
+// StorageOperationDuration{op=name, backend=backend}.
+func Trace(ctx context.Context, name, backend string, fn func(ctx context.Context) error) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name)
+	defer span.End()
+	start := time.Now()
+	err := fn(ctx)
+	StorageOperationDuration.WithLabelValues(name, backend).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package mirror
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// Config enables mirror mode on a registry: reads that miss locally are
+// proxied from Upstream and cached; writes are rejected unless ReadWrite is
+// set. RevalidateTTL bounds how long a cached mutable tag is trusted before
+// its digest is re-checked against Upstream.
+type Config struct {
+	Upstream      *RemoteClient
+	ReadWrite     bool
+	RevalidateTTL time.Duration
+
+	mu      sync.Mutex
+	checked map[string]time.Time
+	digests map[string]digest.Digest
+}
+
+func (c *Config) ttl() time.Duration {
+	if c.RevalidateTTL <= 0 {
+		return time.Minute
+	}
+	return c.RevalidateTTL
+}
+
+// NeedsRevalidation reports whether the cached copy of name:reference has
+// not been checked against upstream within the TTL window, and marks it as
+// checked as of now.
+func (c *Config) NeedsRevalidation(name, reference string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checked == nil {
+		c.checked = map[string]time.Time{}
+	}
+	key := name + "@" + reference
+	if last, ok := c.checked[key]; ok && time.Since(last) < c.ttl() {
+		return false
+	}
+	c.checked[key] = time.Now()
+	return true
+}
+
+// CachedDigest returns the digest that name:reference was last known to
+// have, as recorded by RecordDigest.
+func (c *Config) CachedDigest(name, reference string) (digest.Digest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dgst, ok := c.digests[name+"@"+reference]
+	return dgst, ok
+}
+
+// RecordDigest remembers the digest that name:reference currently resolves
+// to locally, so a later revalidation can tell whether upstream has moved it.
+func (c *Config) RecordDigest(name, reference string, dgst digest.Digest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.digests == nil {
+		c.digests = map[string]digest.Digest{}
+	}
+	c.digests[name+"@"+reference] = dgst
+}
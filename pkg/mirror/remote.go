@@ -0,0 +1,114 @@
+// Package mirror implements pull-through caching in front of a remote
+// modelx registry: reads that miss locally are fetched from upstream,
+// streamed to the client, and persisted so later requests are served
+// entirely from local storage.
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/metrics"
+)
+
+// RemoteClient talks to an upstream modelx (or any OCI distribution
+// compatible) registry, retrying transient failures with exponential
+// backoff.
+type RemoteClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+func (c *RemoteClient) client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *RemoteClient) backoff() time.Duration {
+	if c.Backoff <= 0 {
+		return 200 * time.Millisecond
+	}
+	return c.Backoff
+}
+
+func (c *RemoteClient) maxRetries() int {
+	if c.MaxRetries <= 0 {
+		return 3
+	}
+	return c.MaxRetries
+}
+
+// GetManifest fetches a manifest by tag or digest from upstream. The caller
+// must close body.
+func (c *RemoteClient) GetManifest(ctx context.Context, name, reference string) (contentType string, body io.ReadCloser, err error) {
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.BaseURL, name, reference)
+	resp, err := c.doWithRetry(ctx, http.MethodGet, url)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.Header.Get("Content-Type"), resp.Body, nil
+}
+
+// HeadManifest resolves the current digest of reference upstream, used to
+// revalidate a cached mutable tag without transferring the manifest body.
+func (c *RemoteClient) HeadManifest(ctx context.Context, name, reference string) (digest.Digest, error) {
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.BaseURL, name, reference)
+	resp, err := c.doWithRetry(ctx, http.MethodHead, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return digest.Parse(resp.Header.Get("Docker-Content-Digest"))
+}
+
+// GetBlob fetches a blob by digest from upstream. The caller must close body.
+func (c *RemoteClient) GetBlob(ctx context.Context, name string, dgst digest.Digest) (contentType string, size int64, body io.ReadCloser, err error) {
+	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.BaseURL, name, dgst.String())
+	resp, err := c.doWithRetry(ctx, http.MethodGet, url)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return resp.Header.Get("Content-Type"), resp.ContentLength, resp.Body, nil
+}
+
+func (c *RemoteClient) doWithRetry(ctx context.Context, method, url string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries(); attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.backoff() * time.Duration(1<<uint(attempt-1))):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		metrics.InjectHeaders(ctx, req.Header)
+		resp, err := c.client().Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("upstream %s: status %d", url, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			defer resp.Body.Close()
+			return nil, fmt.Errorf("upstream %s: status %d", url, resp.StatusCode)
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("upstream %s: giving up after %d attempts: %w", url, c.maxRetries()+1, lastErr)
+}
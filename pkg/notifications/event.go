@@ -0,0 +1,47 @@
+// Package notifications delivers push/pull event webhooks, modeled on the
+// Docker distribution notification system: every manifest or blob mutation
+// produces an Event that is batched and POSTed to configured endpoints.
+package notifications
+
+// Action identifies what happened to the target.
+type Action string
+
+const (
+	ActionPush   Action = "push"
+	ActionPull   Action = "pull"
+	ActionDelete Action = "delete"
+	ActionMount  Action = "mount"
+)
+
+// Target identifies the manifest or blob the event is about.
+type Target struct {
+	Repository string `json:"repository"`
+	Digest     string `json:"digest"`
+	MediaType  string `json:"mediaType"`
+	Size       int64  `json:"size"`
+	URL        string `json:"url"`
+}
+
+// RequestInfo captures the originating HTTP request, for audit trails.
+type RequestInfo struct {
+	Addr      string `json:"addr"`
+	Host      string `json:"host"`
+	Method    string `json:"method"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Event is a single notification record.
+type Event struct {
+	ID        string      `json:"id"`
+	Timestamp string      `json:"timestamp"`
+	Action    Action      `json:"action"`
+	Target    Target      `json:"target"`
+	Request   RequestInfo `json:"request"`
+	Actor     string      `json:"actor"`
+}
+
+// Envelope is the batch POSTed to each endpoint, matching the Docker
+// distribution notification wire format.
+type Envelope struct {
+	Events []Event `json:"events"`
+}
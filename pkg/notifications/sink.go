@@ -0,0 +1,294 @@
+package notifications
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Endpoint is one configured webhook target.
+type Endpoint struct {
+	URL    string
+	Secret []byte
+}
+
+// Config configures an EndpointSink.
+type Config struct {
+	Endpoints     []Endpoint
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	// OverflowPath is a disk-backed log that events are appended to when the
+	// in-memory queue is full or delivery keeps failing, so they survive a
+	// restart. NewEndpointSink re-drains it into the queue on startup and
+	// truncates it once the re-queued events are safely buffered in memory.
+	OverflowPath string
+}
+
+func (c Config) queueSize() int {
+	if c.QueueSize <= 0 {
+		return 1024
+	}
+	return c.QueueSize
+}
+
+func (c Config) batchSize() int {
+	if c.BatchSize <= 0 {
+		return 50
+	}
+	return c.BatchSize
+}
+
+func (c Config) flushInterval() time.Duration {
+	if c.FlushInterval <= 0 {
+		return 5 * time.Second
+	}
+	return c.FlushInterval
+}
+
+func (c Config) maxRetries() int {
+	if c.MaxRetries <= 0 {
+		return 5
+	}
+	return c.MaxRetries
+}
+
+type endpointStats struct {
+	Success int64
+	Failure int64
+}
+
+// EndpointSink delivers events to a set of HTTP webhook endpoints,
+// at-least-once, with a bounded in-memory queue and a disk-backed overflow
+// log for events that can't be queued or delivered immediately.
+type EndpointSink struct {
+	cfg    Config
+	queue  chan Event
+	client *http.Client
+
+	mu    sync.Mutex
+	stats map[string]*endpointStats
+
+	overflowMu sync.Mutex
+}
+
+// NewEndpointSink creates a sink and starts its background delivery loop.
+// Call Close to stop it.
+func NewEndpointSink(cfg Config) *EndpointSink {
+	s := &EndpointSink{
+		cfg:    cfg,
+		queue:  make(chan Event, cfg.queueSize()),
+		client: &http.Client{Timeout: 10 * time.Second},
+		stats:  map[string]*endpointStats{},
+	}
+	for _, e := range cfg.Endpoints {
+		s.stats[e.URL] = &endpointStats{}
+	}
+	s.drainOverflow()
+	go s.run()
+	return s
+}
+
+// drainOverflow re-queues events left over from a previous process's
+// overflow log, then truncates the log. Events that no longer fit in the
+// in-memory queue are left on disk (via appendOverflow) rather than dropped.
+func (s *EndpointSink) drainOverflow() {
+	if s.cfg.OverflowPath == "" {
+		return
+	}
+	s.overflowMu.Lock()
+	defer s.overflowMu.Unlock()
+
+	f, err := os.Open(s.cfg.OverflowPath)
+	if err != nil {
+		return
+	}
+	var leftover []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		select {
+		case s.queue <- e:
+		default:
+			leftover = append(leftover, e)
+		}
+	}
+	f.Close()
+
+	if err := os.Truncate(s.cfg.OverflowPath, 0); err != nil {
+		return
+	}
+	for _, e := range leftover {
+		s.appendOverflowLocked(e)
+	}
+}
+
+// Notify enqueues events for asynchronous delivery. If the queue is full the
+// events are appended to the overflow log instead of blocking the caller.
+func (s *EndpointSink) Notify(events ...Event) {
+	for _, e := range events {
+		select {
+		case s.queue <- e:
+		default:
+			s.appendOverflow(e)
+		}
+	}
+}
+
+func (s *EndpointSink) run() {
+	ticker := time.NewTicker(s.cfg.flushInterval())
+	defer ticker.Stop()
+	batch := make([]Event, 0, s.cfg.batchSize())
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.deliver(batch)
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case e, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= s.cfg.batchSize() {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (s *EndpointSink) deliver(batch []Event) {
+	body, err := json.Marshal(Envelope{Events: batch})
+	if err != nil {
+		return
+	}
+	for _, endpoint := range s.cfg.Endpoints {
+		if err := s.deliverWithRetry(endpoint, body); err != nil {
+			for _, e := range batch {
+				s.appendOverflow(e)
+			}
+		}
+	}
+}
+
+func (s *EndpointSink) deliverWithRetry(endpoint Endpoint, body []byte) error {
+	var lastErr error
+	for attempt := 0; attempt < s.cfg.maxRetries(); attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(1<<uint(attempt)) * 100 * time.Millisecond)
+		}
+		req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if len(endpoint.Secret) > 0 {
+			req.Header.Set("X-Modelx-Signature", sign(endpoint.Secret, body))
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			s.record(endpoint.URL, true)
+			return nil
+		}
+		lastErr = errStatus(resp.StatusCode)
+	}
+	s.record(endpoint.URL, false)
+	return lastErr
+}
+
+func (s *EndpointSink) record(url string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, found := s.stats[url]
+	if !found {
+		stats = &endpointStats{}
+		s.stats[url] = stats
+	}
+	if ok {
+		stats.Success++
+	} else {
+		stats.Failure++
+	}
+}
+
+// QueueDepth reports how many events are currently buffered in memory.
+func (s *EndpointSink) QueueDepth() int {
+	return len(s.queue)
+}
+
+func (s *EndpointSink) appendOverflow(e Event) {
+	if s.cfg.OverflowPath == "" {
+		return
+	}
+	s.overflowMu.Lock()
+	defer s.overflowMu.Unlock()
+	s.appendOverflowLocked(e)
+}
+
+// appendOverflowLocked is appendOverflow without acquiring overflowMu, for
+// callers (drainOverflow) that already hold it.
+func (s *EndpointSink) appendOverflowLocked(e Event) {
+	f, err := os.OpenFile(s.cfg.OverflowPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	f.Write(line)
+	f.Write([]byte("\n"))
+}
+
+// MetricsHandler serves /metrics/notifications: queue depth plus per-endpoint
+// success/failure counters.
+func (s *EndpointSink) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	snapshot := make(map[string]endpointStats, len(s.stats))
+	for url, stats := range s.stats {
+		snapshot[url] = *stats
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"queueDepth": s.QueueDepth(),
+		"endpoints":  snapshot,
+	})
+}
+
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type errStatus int
+
+func (e errStatus) Error() string {
+	return "unexpected status code " + strconv.Itoa(int(e))
+}
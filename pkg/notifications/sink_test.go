@@ -0,0 +1,121 @@
+package notifications
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDrainOverflowRequeuesOnRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overflow.log")
+
+	want := []Event{
+		{ID: "1", Action: ActionPush, Target: Target{Repository: "a"}},
+		{ID: "2", Action: ActionDelete, Target: Target{Repository: "b"}},
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range want {
+		line, _ := json.Marshal(e)
+		f.Write(line)
+		f.Write([]byte("\n"))
+	}
+	f.Close()
+
+	// Build the sink without NewEndpointSink's background loop, which would
+	// otherwise race this test to pull the drained events off the queue.
+	cfg := Config{OverflowPath: path, FlushInterval: time.Hour}
+	sink := &EndpointSink{
+		cfg:   cfg,
+		queue: make(chan Event, cfg.queueSize()),
+		stats: map[string]*endpointStats{},
+	}
+	sink.drainOverflow()
+	defer close(sink.queue)
+
+	if depth := sink.QueueDepth(); depth != len(want) {
+		t.Fatalf("QueueDepth() = %d, want %d", depth, len(want))
+	}
+	for _, e := range want {
+		select {
+		case got := <-sink.queue:
+			if got.ID != e.ID {
+				t.Errorf("got event %q, want %q", got.ID, e.ID)
+			}
+		default:
+			t.Fatalf("expected queued event %q", e.ID)
+		}
+	}
+
+	remaining, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 0 {
+		t.Fatalf("overflow log not truncated after drain, got %q", remaining)
+	}
+}
+
+func TestDrainOverflowLeavesUnqueueableEventsOnDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overflow.log")
+
+	want := []Event{
+		{ID: "1", Action: ActionPush},
+		{ID: "2", Action: ActionPush},
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range want {
+		line, _ := json.Marshal(e)
+		f.Write(line)
+		f.Write([]byte("\n"))
+	}
+	f.Close()
+
+	sink := &EndpointSink{
+		cfg:   Config{OverflowPath: path},
+		queue: make(chan Event, 1),
+		stats: map[string]*endpointStats{},
+	}
+	sink.drainOverflow()
+
+	if depth := sink.QueueDepth(); depth != 1 {
+		t.Fatalf("QueueDepth() = %d, want 1", depth)
+	}
+
+	remaining, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	for _, line := range splitLines(remaining) {
+		if len(line) == 0 {
+			continue
+		}
+		count++
+	}
+	if count != 1 {
+		t.Fatalf("overflow log should still hold the event that didn't fit, got %d lines", count)
+	}
+}
+
+func splitLines(b []byte) [][]byte {
+	var lines [][]byte
+	start := 0
+	for i, c := range b {
+		if c == '\n' {
+			lines = append(lines, b[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(b) {
+		lines = append(lines, b[start:])
+	}
+	return lines
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/errors"
+	"kubegems.io/modelx/pkg/mirror"
+	"kubegems.io/modelx/pkg/types"
+)
+
+// rejectWriteInMirrorMode responds 405 and returns true if the registry is
+// configured as a read-only mirror. Handlers that begin a write must call
+// this first; the upload-session PATCH/PUT/DELETE handlers only act on
+// sessions that StartBlobUpload, which calls it, has opened.
+func (s *Registry) rejectWriteInMirrorMode(w http.ResponseWriter) bool {
+	if s.Mirror == nil || s.Mirror.ReadWrite {
+		return false
+	}
+	http.Error(w, "registry is a read-only mirror", http.StatusMethodNotAllowed)
+	return true
+}
+
+// mirrorTagIsStale checks a locally cached manifest against upstream via
+// HEAD, without transferring the manifest body, so NeedsRevalidation's TTL
+// window for a mutable tag actually re-checks the upstream digest instead of
+// trusting the local copy forever. It fails open (reports not-stale) if the
+// HEAD request itself fails, so a flaky or unreachable upstream doesn't take
+// down reads of an already-cached tag.
+func (s *Registry) mirrorTagIsStale(ctx context.Context, name, reference string) bool {
+	upstreamDigest, err := s.Mirror.Upstream.HeadManifest(ctx, name, reference)
+	if err != nil {
+		return false
+	}
+	if cached, ok := s.Mirror.CachedDigest(name, reference); ok && cached == upstreamDigest {
+		return false
+	}
+	return true
+}
+
+// fetchManifestThroughMirror serves GetManifest misses (or stale mutable
+// tags) by pulling from upstream and persisting the result locally before
+// responding.
+func (s *Registry) fetchManifestThroughMirror(w http.ResponseWriter, r *http.Request, name, reference string) {
+	contentType, body, err := s.Mirror.Upstream.GetManifest(r.Context(), name, reference)
+	if err != nil {
+		ResponseError(w, errors.NewManifestUnknownError(reference))
+		return
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	var manifest types.Manifest
+	if err := json.Unmarshal(raw, &manifest); err == nil {
+		if err := s.Manifest.PutManifest(r.Context(), name, reference, contentType, manifest); err == nil {
+			s.Mirror.RecordDigest(name, reference, digest.FromBytes(raw))
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(raw)
+}
+
+// fetchBlobThroughMirror serves GetBlob misses by streaming the blob from
+// upstream to the client while simultaneously writing it to a scratch file,
+// then promotes that file into local storage as a permanent blob.
+func (s *Registry) fetchBlobThroughMirror(w http.ResponseWriter, r *http.Request, name string, dgst digest.Digest) {
+	contentType, size, body, err := s.Mirror.Upstream.GetBlob(r.Context(), name, dgst)
+	if err != nil {
+		ResponseError(w, errors.NewBlobUnknownError(dgst.String()))
+		return
+	}
+	defer body.Close()
+
+	scratch, err := os.CreateTemp("", "modelx-mirror-*")
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	defer os.Remove(scratch.Name())
+	defer scratch.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(io.MultiWriter(w, scratch), body); err != nil {
+		return
+	}
+	if _, err := scratch.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	content := StorageContent{ContentLength: size, ContentType: contentType, Content: scratch}
+	s.Manifest.PutBlob(r.Context(), name, dgst, content)
+}
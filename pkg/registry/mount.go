@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/errors"
+)
+
+// blobMounter is implemented by storage backends that can link an existing
+// blob into another repository without re-transferring its bytes (e.g. an S3
+// CopyObject call or a filesystem hardlink). RegistryStore.MountBlob does not
+// use it: it always streams the blob.
+type blobMounter interface {
+	MountBlob(ctx context.Context, destRepository, srcRepository string, dgst digest.Digest) error
+}
+
+// MountBlob links dgst from srcRepository into destRepository by streaming
+// the blob through GetBlob/PutBlob. No storage backend fast path (S3
+// copy-object, filesystem hardlink) is used.
+func (s *RegistryStore) MountBlob(ctx context.Context, destRepository, srcRepository string, dgst digest.Digest) error {
+	exists, err := s.ExistsBlob(ctx, srcRepository, dgst)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.NewBlobUnknownError(dgst.String())
+	}
+	result, err := s.GetBlob(ctx, srcRepository, dgst)
+	if err != nil {
+		return err
+	}
+	if result.Content.Content != nil {
+		defer result.Content.Content.Close()
+	}
+	_, err = s.PutBlob(ctx, destRepository, dgst, result.Content)
+	return err
+}
+
+// tryMountBlob handles the `mount`/`from` query parameters on the upload
+// start endpoint. It reports whether the request was handled as a mount (in
+// which case the caller must not also open an upload session).
+func (s *Registry) tryMountBlob(w http.ResponseWriter, r *http.Request, name string) bool {
+	query := r.URL.Query()
+	mount, from := query.Get("mount"), query.Get("from")
+	if mount == "" || from == "" {
+		return false
+	}
+	dgst, err := digest.Parse(mount)
+	if err != nil {
+		ResponseError(w, errors.NewDigestInvalidError(mount))
+		return true
+	}
+	if err := s.Manifest.MountBlob(r.Context(), name, from, dgst); err != nil {
+		if errors.IsBlobUnknown(err) {
+			// dgst doesn't exist in from: per the distribution spec this is
+			// not an error, the client just falls back to a normal upload.
+			return false
+		}
+		ResponseError(w, err)
+		return true
+	}
+	w.Header().Set("Location", BlobLocation(name, dgst))
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	w.WriteHeader(http.StatusCreated)
+	return true
+}
+
+// BlobLocation builds the canonical location of a stored blob.
+func BlobLocation(name string, dgst digest.Digest) string {
+	return "/v2/" + name + "/blobs/" + dgst.String()
+}
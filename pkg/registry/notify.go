@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/notifications"
+)
+
+// notify builds an Event from the originating request and fires it at the
+// registry's configured notification sink, if any. It is a no-op when
+// Notifications is unset so handlers can call it unconditionally.
+func (s *Registry) notify(r *http.Request, action notifications.Action, repository string, dgst digest.Digest, mediaType string, size int64) {
+	if s.Notifications == nil {
+		return
+	}
+	s.Notifications.Notify(notifications.Event{
+		ID:        digest.FromString(repository + string(action) + dgst.String() + time.Now().String()).String(),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Action:    action,
+		Target: notifications.Target{
+			Repository: repository,
+			Digest:     dgst.String(),
+			MediaType:  mediaType,
+			Size:       size,
+			URL:        BlobLocation(repository, dgst),
+		},
+		Request: notifications.RequestInfo{
+			Addr:      r.RemoteAddr,
+			Host:      r.Host,
+			Method:    r.Method,
+			UserAgent: r.UserAgent(),
+		},
+		Actor: actorFromRequest(r),
+	})
+}
+
+// actorFromRequest reports who performed the request. Until the auth
+// subsystem's identity is threaded through the context, this falls back to
+// the remote address.
+func actorFromRequest(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return r.RemoteAddr
+}
+
+// DeleteBlob removes a blob from a repository for DELETE
+// /v2/<name>/blobs/<digest>.
+func (s *Registry) DeleteBlob(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
+	BlobDigestFun(w, r, func(ctx context.Context, repository string, dgst digest.Digest) {
+		if err := s.Manifest.DeleteBlob(r.Context(), repository, dgst); err != nil {
+			ResponseError(w, err)
+			return
+		}
+		s.notify(r, notifications.ActionDelete, repository, dgst, "", 0)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
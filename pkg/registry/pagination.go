@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"kubegems.io/modelx/pkg/errors"
+)
+
+// defaultListN is used when the client omits ?n= entirely.
+const defaultListN = 100
+
+// ListOptions carries the OCI-style pagination cursor: at most N entries
+// strictly greater than Last, in lexicographic order.
+type ListOptions struct {
+	N    int
+	Last string
+}
+
+// ParseListOptions reads the `n` and `last` query parameters used by the
+// catalog and tag listing endpoints.
+func ParseListOptions(r *http.Request) (ListOptions, error) {
+	query := r.URL.Query()
+	opts := ListOptions{N: defaultListN, Last: query.Get("last")}
+	if n := query.Get("n"); n != "" {
+		parsed, err := strconv.Atoi(n)
+		if err != nil || parsed < 0 {
+			return ListOptions{}, errors.NewPaginationInvalidError(n)
+		}
+		opts.N = parsed
+	}
+	return opts, nil
+}
+
+// SetNextLink sets the RFC 5988 Link header pointing at the next page,
+// reusing the incoming request's path and search query.
+func SetNextLink(w http.ResponseWriter, r *http.Request, opts ListOptions, last string) {
+	query := r.URL.Query()
+	query.Set("n", strconv.Itoa(opts.N))
+	query.Set("last", last)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -7,15 +7,44 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/auth"
 	"kubegems.io/modelx/pkg/errors"
+	"kubegems.io/modelx/pkg/metrics"
+	"kubegems.io/modelx/pkg/mirror"
+	"kubegems.io/modelx/pkg/notifications"
+	"kubegems.io/modelx/pkg/signing"
 	"kubegems.io/modelx/pkg/types"
 )
 
 type Registry struct {
 	Manifest *RegistryStore
+
+	// Uploads backs the chunked/resumable blob upload endpoints. It is
+	// initialized lazily on first use; prefer the uploads() accessor.
+	Uploads     *UploadSessionStore
+	uploadsOnce sync.Once
+
+	// Mirror, when set, makes this registry act as a pull-through cache in
+	// front of an upstream modelx registry.
+	Mirror *mirror.Config
+
+	// Notifications, when set, receives an event for every successful
+	// manifest/blob mutation.
+	Notifications *notifications.EndpointSink
+
+	// Verifier, when set, backs ?verify=true manifest pulls and signature
+	// lookups against a trusted key source.
+	Verifier *signing.Verifier
+
+	// Auth, when set, is consulted by NewRouter to require a bearer token
+	// granting the requested scope before a /v2 request reaches its handler.
+	Auth       auth.Authorizer
+	AuthConfig auth.Config
 }
 
 func (s *Registry) HeadManifest(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +62,12 @@ func (s *Registry) HeadManifest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Registry) GetGlobalIndex(w http.ResponseWriter, r *http.Request) {
-	index, err := s.Manifest.GetGlobalIndex(r.Context(), r.URL.Query().Get("search"))
+	opts, err := ParseListOptions(r)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	index, hasMore, err := s.Manifest.GetGlobalIndex(r.Context(), r.URL.Query().Get("search"), opts)
 	if err != nil {
 		if IsS3StorageNotFound(err) {
 			ResponseOK(w, types.Index{})
@@ -41,12 +75,20 @@ func (s *Registry) GetGlobalIndex(w http.ResponseWriter, r *http.Request) {
 		ResponseError(w, err)
 		return
 	}
+	if hasMore && len(index.Manifests) > 0 {
+		SetNextLink(w, r, opts, index.Manifests[len(index.Manifests)-1].Name)
+	}
 	ResponseOK(w, index)
 }
 
 func (s *Registry) GetIndex(w http.ResponseWriter, r *http.Request) {
 	name, _ := GetRepositoryReference(r)
-	index, err := s.Manifest.GetIndex(r.Context(), name, r.URL.Query().Get("search"))
+	opts, err := ParseListOptions(r)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	index, hasMore, err := s.Manifest.GetIndex(r.Context(), name, r.URL.Query().Get("search"), opts)
 	if err != nil {
 		if IsS3StorageNotFound(err) {
 			err = errors.NewIndexUnknownError(name)
@@ -54,10 +96,16 @@ func (s *Registry) GetIndex(w http.ResponseWriter, r *http.Request) {
 		ResponseError(w, err)
 		return
 	}
+	if hasMore && len(index.Manifests) > 0 {
+		SetNextLink(w, r, opts, index.Manifests[len(index.Manifests)-1].Name)
+	}
 	ResponseOK(w, index)
 }
 
 func (s *Registry) DeleteIndex(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
 	name, _ := GetRepositoryReference(r)
 	if err := s.Manifest.RemoveIndex(r.Context(), name); err != nil {
 		if IsS3StorageNotFound(err) {
@@ -71,18 +119,52 @@ func (s *Registry) DeleteIndex(w http.ResponseWriter, r *http.Request) {
 
 func (s *Registry) GetManifest(w http.ResponseWriter, r *http.Request) {
 	name, reference := GetRepositoryReference(r)
-	manifest, err := s.Manifest.GetManifest(r.Context(), name, reference)
+	if s.Mirror != nil && s.Mirror.NeedsRevalidation(name, reference) {
+		exists, _ := s.Manifest.Exists(r.Context(), name, reference)
+		if !exists {
+			s.fetchManifestThroughMirror(w, r, name, reference)
+			return
+		}
+		if stale := s.mirrorTagIsStale(r.Context(), name, reference); stale {
+			s.fetchManifestThroughMirror(w, r, name, reference)
+			return
+		}
+	}
+	var manifest types.Manifest
+	err := metrics.Trace(r.Context(), "GetManifest", "store", func(ctx context.Context) error {
+		var err error
+		manifest, err = s.Manifest.GetManifest(ctx, name, reference)
+		return err
+	})
 	if err != nil {
+		if s.Mirror != nil {
+			s.fetchManifestThroughMirror(w, r, name, reference)
+			return
+		}
 		ResponseError(w, err)
 		return
 	}
+	if r.URL.Query().Get("verify") == "true" {
+		if err := s.verifyManifest(r, name, resolveSubjectDigest(reference, manifest)); err != nil {
+			ResponseError(w, err)
+			return
+		}
+	}
 	ResponseOK(w, manifest)
 }
 
 func (s *Registry) PutManifest(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
 	name, reference := GetRepositoryReference(r)
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		ResponseError(w, errors.NewManifestInvalidError(err))
+		return
+	}
 	var manifest types.Manifest
-	if err := json.NewDecoder(r.Body).Decode(&manifest); err != nil {
+	if err := json.Unmarshal(raw, &manifest); err != nil {
 		ResponseError(w, errors.NewManifestInvalidError(err))
 		return
 	}
@@ -91,15 +173,25 @@ func (s *Registry) PutManifest(w http.ResponseWriter, r *http.Request) {
 		ResponseError(w, err)
 		return
 	}
+	if s.Mirror != nil {
+		s.Mirror.RecordDigest(name, reference, digest.FromBytes(raw))
+	}
+	s.notify(r, notifications.ActionPush, name, digest.FromBytes(raw), contenttype, int64(len(raw)))
 	w.WriteHeader(http.StatusCreated)
 }
 
 func (s *Registry) DeleteManifest(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
 	name, reference := GetRepositoryReference(r)
 	if err := s.Manifest.DeleteManifest(r.Context(), name, reference); err != nil {
 		ResponseError(w, err)
 		return
 	}
+	if dgst, err := digest.Parse(reference); err == nil {
+		s.notify(r, notifications.ActionDelete, name, dgst, "", 0)
+	}
 	w.WriteHeader(http.StatusAccepted)
 }
 
@@ -126,6 +218,9 @@ func (s *Registry) HeadBlob(w http.ResponseWriter, r *http.Request) {
 // 如果客户端 包含 contentLength 则直接上传
 // 如果客户端 不包含 contentLength 则返回一个 Location 后续上传至该地址
 func (s *Registry) PutBlob(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
 	BlobDigestFun(w, r, func(ctx context.Context, repository string, digest digest.Digest) {
 		contentType := r.Header.Get("Content-Type")
 		if contentType == "" {
@@ -137,11 +232,17 @@ func (s *Registry) PutBlob(w http.ResponseWriter, r *http.Request) {
 			ContentType:   contentType,
 			Content:       r.Body,
 		}
-		result, err := s.Manifest.PutBlob(r.Context(), repository, digest, content)
+		storeCtx, span := metrics.StartChildSpan(ctx, "PutBlob")
+		storeStart := time.Now()
+		result, err := s.Manifest.PutBlob(storeCtx, repository, digest, content)
+		metrics.StorageOperationDuration.WithLabelValues("PutBlob", "store").Observe(time.Since(storeStart).Seconds())
+		span.End()
 		if err != nil {
 			ResponseError(w, err)
 			return
 		}
+		metrics.BlobBytesTransferred.WithLabelValues("in").Add(float64(r.ContentLength))
+		s.notify(r, notifications.ActionPush, repository, digest, contentType, r.ContentLength)
 		if location := result.RedirectLocation; location != "" {
 			w.Header().Set("Location", location)
 			w.WriteHeader(http.StatusTemporaryRedirect)
@@ -153,8 +254,18 @@ func (s *Registry) PutBlob(w http.ResponseWriter, r *http.Request) {
 
 func (s *Registry) GetBlob(w http.ResponseWriter, r *http.Request) {
 	BlobDigestFun(w, r, func(ctx context.Context, repository string, digest digest.Digest) {
+		if s.Mirror != nil {
+			if exists, _ := s.Manifest.ExistsBlob(r.Context(), repository, digest); !exists {
+				s.fetchBlobThroughMirror(w, r, repository, digest)
+				return
+			}
+		}
 		result, err := s.Manifest.GetBlob(r.Context(), repository, digest)
 		if err != nil {
+			if s.Mirror != nil {
+				s.fetchBlobThroughMirror(w, r, repository, digest)
+				return
+			}
 			ResponseError(w, err)
 			return
 		}
@@ -167,7 +278,8 @@ func (s *Registry) GetBlob(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Encoding", result.Content.ContentEncoding)
 			w.WriteHeader(http.StatusOK)
 
-			io.Copy(w, result.Content.Content)
+			written, _ := io.Copy(w, result.Content.Content)
+			metrics.BlobBytesTransferred.WithLabelValues("out").Add(float64(written))
 		}
 		return
 	})
@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"regexp"
+
+	"github.com/gorilla/mux"
+	"kubegems.io/modelx/pkg/auth"
+	"kubegems.io/modelx/pkg/metrics"
+)
+
+// nameComponent is a single path segment of a repository name, per the OCI
+// distribution spec grammar.
+const nameComponent = `[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*`
+
+// NameRegexpPattern matches a full, possibly multi-segment, repository name
+// such as "team-a/llm/llama3-8b-instruct".
+const NameRegexpPattern = nameComponent + `(?:/` + nameComponent + `)*`
+
+// referenceRegexpPattern matches a tag or digest reference. It excludes `/`
+// so the router can tell where a multi-segment name ends.
+const referenceRegexpPattern = `[^/]+`
+
+// digestRegexpPattern matches an algorithm:hex digest.
+const digestRegexpPattern = `[a-zA-Z0-9]+:[a-fA-F0-9]+`
+
+// uuidRegexpPattern matches an upload session id.
+const uuidRegexpPattern = `[a-fA-F0-9-]+`
+
+// NameRegexp validates a complete repository name.
+var NameRegexp = regexp.MustCompile(`^` + NameRegexpPattern + `$`)
+
+// repoPath prefixes pattern (e.g. "/manifests/{reference:...}") with the
+// repository name variable, yielding a single, non-nested route pattern
+// relative to the /v2 subrouter.
+//
+// gorilla/mux (as of v1.8) re-evaluates every matcher in a route's parent
+// chain on a successful match, including an intermediate PathPrefix route's
+// own (unanchored) regex. Composing a slash-containing {name} variable via
+// `.PathPrefix(...).Subrouter()` lets that outer prefix greedily re-capture
+// "name" all the way to the end of the path after the inner leaf route
+// already matched correctly. Building one flat pattern per leaf route avoids
+// the extra matcher entirely. (The /v2 subrouter itself is a plain literal
+// prefix with no capturing groups, so it isn't subject to this problem.)
+func repoPath(pattern string) string {
+	return "/{name:" + NameRegexpPattern + "}" + pattern
+}
+
+// NewRouter builds the registry's mux router: the v2 distribution API under
+// /v2, plus operational endpoints (/metrics, /metrics/notifications).
+// Repository names may contain multiple path segments (e.g.
+// "team/model/variant"); the trailing action component
+// (manifests/blobs/index/uploads) is matched with its own restrictive regex
+// so the router still knows where the repository name ends, even when a
+// path component of the name itself looks like "manifests" or "blobs".
+func NewRouter(registry *Registry) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(metrics.Middleware)
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	if registry.Notifications != nil {
+		router.HandleFunc("/metrics/notifications", registry.Notifications.MetricsHandler).Methods("GET")
+	}
+
+	v2 := router.PathPrefix("/v2").Subrouter()
+	if registry.Auth != nil {
+		v2.Use(auth.Middleware(registry.Auth, registry.AuthConfig))
+	}
+
+	v2.HandleFunc("/_catalog", registry.GetGlobalIndex).Methods("GET")
+
+	v2.HandleFunc(repoPath("/index"), registry.GetIndex).Methods("GET")
+	v2.HandleFunc(repoPath("/index"), registry.DeleteIndex).Methods("DELETE")
+
+	v2.HandleFunc(repoPath("/manifests/{reference:"+referenceRegexpPattern+"}"), registry.HeadManifest).Methods("HEAD")
+	v2.HandleFunc(repoPath("/manifests/{reference:"+referenceRegexpPattern+"}"), registry.GetManifest).Methods("GET")
+	v2.HandleFunc(repoPath("/manifests/{reference:"+referenceRegexpPattern+"}"), registry.PutManifest).Methods("PUT")
+	v2.HandleFunc(repoPath("/manifests/{reference:"+referenceRegexpPattern+"}"), registry.DeleteManifest).Methods("DELETE")
+
+	v2.HandleFunc(repoPath("/blobs/{digest:"+digestRegexpPattern+"}"), registry.HeadBlob).Methods("HEAD")
+	v2.HandleFunc(repoPath("/blobs/{digest:"+digestRegexpPattern+"}"), registry.GetBlob).Methods("GET")
+	v2.HandleFunc(repoPath("/blobs/{digest:"+digestRegexpPattern+"}"), registry.DeleteBlob).Methods("DELETE")
+
+	v2.HandleFunc(repoPath("/blobs/uploads/"), registry.StartBlobUpload).Methods("POST")
+	v2.HandleFunc(repoPath("/blobs/uploads/{uuid:"+uuidRegexpPattern+"}"), registry.GetBlobUploadStatus).Methods("GET")
+	v2.HandleFunc(repoPath("/blobs/uploads/{uuid:"+uuidRegexpPattern+"}"), registry.PatchBlobUpload).Methods("PATCH")
+	v2.HandleFunc(repoPath("/blobs/uploads/{uuid:"+uuidRegexpPattern+"}"), registry.CompleteBlobUpload).Methods("PUT")
+	v2.HandleFunc(repoPath("/blobs/uploads/{uuid:"+uuidRegexpPattern+"}"), registry.CancelBlobUpload).Methods("DELETE")
+
+	v2.HandleFunc(repoPath("/manifest/{reference:"+referenceRegexpPattern+"}/signatures/"), registry.PutManifestSignature).Methods("POST")
+	v2.HandleFunc(repoPath("/referrers/{digest:"+digestRegexpPattern+"}"), registry.GetReferrers).Methods("GET")
+
+	return router
+}
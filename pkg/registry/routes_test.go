@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestNewRouterRepositoryNames(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		wantName   string
+		wantReason string
+		matches    bool
+	}{
+		{name: "single segment", path: "/v2/llama3/manifests/latest", wantName: "llama3", matches: true},
+		{name: "deep nested", path: "/v2/team-a/llm/llama3-8b-instruct/manifests/latest", wantName: "team-a/llm/llama3-8b-instruct", matches: true},
+		{name: "component named manifests", path: "/v2/team/manifests/models/manifests/latest", wantName: "team/manifests/models", matches: true},
+		{name: "component named blobs", path: "/v2/team/blobs/models/manifests/latest", wantName: "team/blobs/models", matches: true},
+		{name: "uppercase rejected", path: "/v2/Team/manifests/latest", matches: false},
+		{name: "empty segment rejected", path: "/v2/team//model/manifests/latest", matches: false},
+	}
+
+	router := NewRouter(&Registry{})
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			var match mux.RouteMatch
+			ok := router.Match(req, &match)
+			if ok != tc.matches {
+				t.Fatalf("Match(%q) = %v, want %v", tc.path, ok, tc.matches)
+			}
+			if !tc.matches {
+				return
+			}
+			if got := match.Vars["name"]; got != tc.wantName {
+				t.Fatalf("name = %q, want %q", got, tc.wantName)
+			}
+		})
+	}
+}
+
+func TestNameRegexp(t *testing.T) {
+	valid := []string{"llama3", "team-a/llm/llama3-8b-instruct", "a.b_c", "a__b"}
+	invalid := []string{"", "Team", "/leading-slash", "trailing-slash/", "a//b"}
+
+	for _, name := range valid {
+		if !NameRegexp.MatchString(name) {
+			t.Errorf("expected %q to be a valid repository name", name)
+		}
+	}
+	for _, name := range invalid {
+		if NameRegexp.MatchString(name) {
+			t.Errorf("expected %q to be an invalid repository name", name)
+		}
+	}
+}
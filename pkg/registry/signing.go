@@ -0,0 +1,170 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/errors"
+	"kubegems.io/modelx/pkg/signing"
+	"kubegems.io/modelx/pkg/types"
+)
+
+// signatureManifestReference derives a deterministic manifest reference for
+// the signature of subject, so repeated signing of the same manifest
+// overwrites the previous signature rather than accumulating garbage.
+func signatureManifestReference(subject digest.Digest) string {
+	return "sig-" + subject.Encoded()
+}
+
+// resolveSubjectDigest returns the real Docker-Content-Digest of the manifest
+// stored at name:reference. References are almost always already a digest
+// (that's how OCI clients address the referrers/subject API), in which case
+// it's returned directly. A tag reference has no raw bytes left to hash
+// against by the time the manifest has been parsed back out of storage, so
+// it falls back to re-marshaling; that fallback only matches the real digest
+// for manifests whose JSON round-trips canonically.
+func resolveSubjectDigest(reference string, manifest types.Manifest) digest.Digest {
+	if dgst, err := digest.Parse(reference); err == nil {
+		return dgst
+	}
+	return digest.FromBytes(mustMarshal(manifest))
+}
+
+// PutManifestSignature stores a detached signature blob for POST
+// /v2/<name>/manifest/<reference>/signatures/, linking it to the subject
+// manifest via an OCI `subject` descriptor so it shows up in GetReferrers.
+func (s *Registry) PutManifestSignature(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
+	name, reference := GetRepositoryReference(r)
+	subjectManifest, err := s.Manifest.GetManifest(r.Context(), name, reference)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	subjectDigest := resolveSubjectDigest(reference, subjectManifest)
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	mediaType := r.Header.Get("Content-Type")
+	if mediaType == "" {
+		mediaType = signing.CosignSimpleSigningMediaType
+	}
+	sigDigest := digest.FromBytes(raw)
+	if _, err := s.Manifest.PutBlob(r.Context(), name, sigDigest, StorageContent{
+		ContentLength: int64(len(raw)),
+		ContentType:   mediaType,
+		Content:       io.NopCloser(bytes.NewReader(raw)),
+	}); err != nil {
+		ResponseError(w, err)
+		return
+	}
+
+	sigManifest := types.Manifest{
+		MediaType: mediaType,
+		Config:    types.Descriptor{Digest: sigDigest, MediaType: mediaType, Size: int64(len(raw))},
+		Subject:   &types.Descriptor{Digest: subjectDigest, MediaType: subjectManifest.MediaType},
+	}
+	sigRef := signatureManifestReference(subjectDigest)
+	if err := s.Manifest.PutManifest(r.Context(), name, sigRef, mediaType, sigManifest); err != nil {
+		ResponseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetReferrers lists the manifests (signatures, SBOMs, etc.) whose `subject`
+// descriptor points at digest, for GET /v2/<name>/referrers/<digest>.
+func (s *Registry) GetReferrers(w http.ResponseWriter, r *http.Request) {
+	name, _ := GetRepositoryReference(r)
+	dgst, err := digest.Parse(mux.Vars(r)["digest"])
+	if err != nil {
+		ResponseError(w, errors.NewDigestInvalidError(mux.Vars(r)["digest"]))
+		return
+	}
+	result := types.Index{}
+	err = s.forEachManifest(r.Context(), name, func(entry types.Descriptor, manifest types.Manifest) {
+		if manifest.Subject != nil && manifest.Subject.Digest == dgst {
+			result.Manifests = append(result.Manifests, entry)
+		}
+	})
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	ResponseOK(w, result)
+}
+
+// forEachManifest walks every manifest in name's index, following the
+// `last` pagination cursor across pages until the store reports no more,
+// so callers see referrers/signatures beyond the first page.
+func (s *Registry) forEachManifest(ctx context.Context, name string, fn func(entry types.Descriptor, manifest types.Manifest)) error {
+	opts := ListOptions{N: defaultListN}
+	for {
+		index, hasMore, err := s.Manifest.GetIndex(ctx, name, "", opts)
+		if err != nil {
+			return err
+		}
+		for _, entry := range index.Manifests {
+			manifest, err := s.Manifest.GetManifest(ctx, name, entry.Name)
+			if err != nil {
+				continue
+			}
+			fn(entry, manifest)
+		}
+		if !hasMore || len(index.Manifests) == 0 {
+			return nil
+		}
+		opts.Last = index.Manifests[len(index.Manifests)-1].Name
+	}
+}
+
+// verifyManifest checks ?verify=true requests against the registry's
+// configured trust store, returning an error if no trusted signature covers
+// dgst.
+func (s *Registry) verifyManifest(r *http.Request, name string, dgst digest.Digest) error {
+	if s.Verifier == nil {
+		return errors.NewManifestUnverifiedError(dgst.String())
+	}
+	verified := false
+	err := s.forEachManifest(r.Context(), name, func(entry types.Descriptor, manifest types.Manifest) {
+		if verified || manifest.Subject == nil || manifest.Subject.Digest != dgst {
+			return
+		}
+		blob, err := s.Manifest.GetBlob(r.Context(), name, manifest.Config.Digest)
+		if err != nil {
+			return
+		}
+		raw, err := io.ReadAll(blob.Content.Content)
+		if err != nil {
+			return
+		}
+		if err := s.Verifier.Verify(r.Context(), dgst, manifest.MediaType, raw); err == nil {
+			verified = true
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return errors.NewManifestUnverifiedError(dgst.String())
+	}
+	return nil
+}
+
+func mustMarshal(v any) []byte {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,378 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/opencontainers/go-digest"
+	"kubegems.io/modelx/pkg/errors"
+	"kubegems.io/modelx/pkg/metrics"
+	"kubegems.io/modelx/pkg/notifications"
+)
+
+// defaultUploadSessionTTL is how long an upload session may sit idle before
+// the garbage collector reclaims it and the temporary chunk data it holds.
+const defaultUploadSessionTTL = 24 * time.Hour
+
+// UploadSession tracks the state of a single in-progress chunked blob upload,
+// as created by a POST to the upload-session endpoint and advanced by
+// subsequent PATCH requests.
+type UploadSession struct {
+	UUID       string
+	Repository string
+	Offset     int64
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+	// PartsRef is the local filesystem path accumulating the uploaded bytes
+	// until the session is finalized into a permanent blob.
+	PartsRef string
+
+	// mu serializes Append/Finalize calls against this one session, so a
+	// slow chunk upload only blocks retries of its own session rather than
+	// every other session in the store.
+	mu sync.Mutex
+}
+
+// UploadSessionStore keeps track of open upload sessions in memory and
+// spills the uploaded bytes to a scratch file on disk. It is safe for
+// concurrent use.
+type UploadSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*UploadSession
+	dir      string
+	ttl      time.Duration
+}
+
+// NewUploadSessionStore creates an UploadSessionStore that writes chunk data
+// under dir (created if necessary) and expires idle sessions after ttl.
+func NewUploadSessionStore(dir string, ttl time.Duration) *UploadSessionStore {
+	if ttl <= 0 {
+		ttl = defaultUploadSessionTTL
+	}
+	return &UploadSessionStore{
+		sessions: map[string]*UploadSession{},
+		dir:      dir,
+		ttl:      ttl,
+	}
+}
+
+func (u *UploadSessionStore) Create(repository string) (*UploadSession, error) {
+	if err := os.MkdirAll(u.dir, 0o755); err != nil {
+		return nil, err
+	}
+	id := uuid.NewString()
+	f, err := os.Create(u.partsPath(id))
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	now := time.Now()
+	session := &UploadSession{
+		UUID:       id,
+		Repository: repository,
+		StartedAt:  now,
+		UpdatedAt:  now,
+		PartsRef:   u.partsPath(id),
+	}
+	u.mu.Lock()
+	u.sessions[id] = session
+	u.mu.Unlock()
+	metrics.ActiveUploadSessions.Inc()
+	return session, nil
+}
+
+func (u *UploadSessionStore) Get(repository, id string) (*UploadSession, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	session, ok := u.sessions[id]
+	if !ok || session.Repository != repository {
+		return nil, errors.NewBlobUploadUnknownError(id)
+	}
+	return session, nil
+}
+
+// Append writes content at [start,end] into the session's scratch file.
+// A PATCH that repeats the previously applied range with identical length is
+// treated as an idempotent retry and simply returns the current offset.
+func (u *UploadSessionStore) Append(repository, id string, start, end int64, content io.Reader) (*UploadSession, error) {
+	session, err := u.Get(repository, id)
+	if err != nil {
+		return nil, err
+	}
+	// Hold only this session's lock, not the store-wide u.mu, across the
+	// write: content is a live HTTP request body and can take a long time
+	// (or stall) to copy. Holding the store lock here would serialize every
+	// other session's Create/Get/Abort/GC and every other repository's
+	// uploads behind one slow chunk.
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if start > session.Offset {
+		return nil, errors.NewContentRangeInvalidError(fmt.Sprintf("expected start <= %d, got %d", session.Offset, start))
+	}
+	if end < session.Offset {
+		// Fully covered by a previous chunk: idempotent retry, no-op.
+		return session, nil
+	}
+	f, err := os.OpenFile(session.PartsRef, os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	written, err := io.Copy(f, content)
+	if err != nil {
+		return nil, err
+	}
+	metrics.BlobBytesTransferred.WithLabelValues("in").Add(float64(written))
+	if newOffset := start + written; newOffset > session.Offset {
+		session.Offset = newOffset
+	}
+	session.UpdatedAt = time.Now()
+	return session, nil
+}
+
+func (u *UploadSessionStore) Abort(repository, id string) error {
+	session, err := u.Get(repository, id)
+	if err != nil {
+		return err
+	}
+	// Take the session's own lock before removing its scratch file, so this
+	// can't race an in-flight Append that's still writing to the same file
+	// under the same lock. u.mu and session.mu are never held at once, so
+	// there's no ordering to get wrong between this and GC/Complete.
+	session.mu.Lock()
+	err = os.Remove(session.PartsRef)
+	session.mu.Unlock()
+
+	u.mu.Lock()
+	delete(u.sessions, id)
+	u.mu.Unlock()
+	metrics.ActiveUploadSessions.Dec()
+	return err
+}
+
+// Finalize appends an optional final chunk and returns an open reader over
+// the assembled blob content. It does not check the content against any
+// digest; callers verify it, and are responsible for closing the returned
+// file and removing the session once it has been promoted to a permanent
+// blob.
+func (u *UploadSessionStore) Finalize(repository, id string, start, end int64, final io.Reader) (*UploadSession, *os.File, error) {
+	if final != nil {
+		if _, err := u.Append(repository, id, start, end, final); err != nil {
+			return nil, nil, err
+		}
+	}
+	session, err := u.Get(repository, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(session.PartsRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, f, nil
+}
+
+func (u *UploadSessionStore) Complete(repository, id string) {
+	u.mu.Lock()
+	session := u.sessions[id]
+	delete(u.sessions, id)
+	u.mu.Unlock()
+	if session == nil {
+		return
+	}
+	session.mu.Lock()
+	os.Remove(session.PartsRef)
+	session.mu.Unlock()
+	metrics.ActiveUploadSessions.Dec()
+}
+
+// GC removes sessions that have been idle for longer than the store's TTL,
+// reclaiming their scratch files. Intended to be called periodically from a
+// background goroutine started alongside the registry server.
+//
+// It never holds u.mu and a session's own mu at the same time: it snapshots
+// the session list under u.mu, then locks each session individually to read
+// UpdatedAt and remove its file, matching how Append/Abort/Complete only
+// ever take one of the two locks at once.
+func (u *UploadSessionStore) GC(ctx context.Context) {
+	u.mu.Lock()
+	sessions := make([]*UploadSession, 0, len(u.sessions))
+	for _, session := range u.sessions {
+		sessions = append(sessions, session)
+	}
+	u.mu.Unlock()
+
+	for _, session := range sessions {
+		session.mu.Lock()
+		expired := time.Since(session.UpdatedAt) > u.ttl
+		if expired {
+			os.Remove(session.PartsRef)
+		}
+		session.mu.Unlock()
+		if !expired {
+			continue
+		}
+		u.mu.Lock()
+		delete(u.sessions, session.UUID)
+		u.mu.Unlock()
+		metrics.ActiveUploadSessions.Dec()
+	}
+}
+
+func (u *UploadSessionStore) partsPath(id string) string {
+	return u.dir + "/" + id + ".part"
+}
+
+// uploads lazily initializes the registry's upload session store on first use.
+func (s *Registry) uploads() *UploadSessionStore {
+	s.uploadsOnce.Do(func() {
+		s.Uploads = NewUploadSessionStore(os.TempDir()+"/modelx-uploads", defaultUploadSessionTTL)
+	})
+	return s.Uploads
+}
+
+// UploadLocation builds the canonical location of an upload session, as
+// returned in the Location header of every step of the upload flow.
+func UploadLocation(name, id string) string {
+	return "/v2/" + name + "/blobs/uploads/" + id
+}
+
+// StartBlobUpload begins a new resumable upload session for POST
+// /v2/<name>/blobs/uploads/.
+func (s *Registry) StartBlobUpload(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWriteInMirrorMode(w) {
+		return
+	}
+	name, _ := GetRepositoryReference(r)
+	if s.tryMountBlob(w, r, name) {
+		return
+	}
+	session, err := s.uploads().Create(name)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	w.Header().Set("Location", UploadLocation(name, session.UUID))
+	w.Header().Set("Docker-Upload-UUID", session.UUID)
+	w.Header().Set("Range", "0-0")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// PatchBlobUpload appends a chunk to an open upload session for PATCH
+// /v2/<name>/blobs/uploads/<uuid>.
+func (s *Registry) PatchBlobUpload(w http.ResponseWriter, r *http.Request) {
+	name, _ := GetRepositoryReference(r)
+	id := mux.Vars(r)["uuid"]
+	start, end, err := ParseAndCheckContentRange(r.Header)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	session, err := s.uploads().Append(name, id, start, end, r.Body)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	w.Header().Set("Location", UploadLocation(name, id))
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", session.Offset))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// GetBlobUploadStatus reports the current offset of an open upload session
+// for GET /v2/<name>/blobs/uploads/<uuid>.
+func (s *Registry) GetBlobUploadStatus(w http.ResponseWriter, r *http.Request) {
+	name, _ := GetRepositoryReference(r)
+	id := mux.Vars(r)["uuid"]
+	session, err := s.uploads().Get(name, id)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	w.Header().Set("Location", UploadLocation(name, id))
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", session.Offset))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompleteBlobUpload finalizes an upload session for PUT
+// /v2/<name>/blobs/uploads/<uuid>?digest=<digest>, optionally carrying a
+// final chunk in the request body.
+func (s *Registry) CompleteBlobUpload(w http.ResponseWriter, r *http.Request) {
+	name, _ := GetRepositoryReference(r)
+	id := mux.Vars(r)["uuid"]
+	digeststr := r.URL.Query().Get("digest")
+	dgst, err := digest.Parse(digeststr)
+	if err != nil {
+		ResponseError(w, errors.NewDigestInvalidError(digeststr))
+		return
+	}
+	var start, end int64 = -1, -1
+	if r.ContentLength > 0 {
+		start, end, err = ParseAndCheckContentRange(r.Header)
+		if err != nil {
+			ResponseError(w, err)
+			return
+		}
+	}
+	var final io.Reader
+	if start >= 0 {
+		final = r.Body
+	}
+	session, f, err := s.uploads().Finalize(name, id, start, end, final)
+	if err != nil {
+		ResponseError(w, err)
+		return
+	}
+	defer f.Close()
+
+	verifier := dgst.Verifier()
+	if _, err := io.Copy(verifier, f); err != nil {
+		ResponseError(w, err)
+		return
+	}
+	if !verifier.Verified() {
+		s.uploads().Complete(name, id)
+		ResponseError(w, errors.NewDigestInvalidError(digeststr))
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		ResponseError(w, err)
+		return
+	}
+	content := StorageContent{
+		ContentLength: session.Offset,
+		ContentType:   r.Header.Get("Content-Type"),
+		Content:       f,
+	}
+	if _, err := s.Manifest.PutBlob(r.Context(), name, dgst, content); err != nil {
+		ResponseError(w, err)
+		return
+	}
+	s.uploads().Complete(name, id)
+	s.notify(r, notifications.ActionPush, name, dgst, content.ContentType, content.ContentLength)
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	w.WriteHeader(http.StatusCreated)
+}
+
+// CancelBlobUpload aborts an open upload session for DELETE
+// /v2/<name>/blobs/uploads/<uuid>.
+func (s *Registry) CancelBlobUpload(w http.ResponseWriter, r *http.Request) {
+	name, _ := GetRepositoryReference(r)
+	id := mux.Vars(r)["uuid"]
+	if err := s.uploads().Abort(name, id); err != nil {
+		ResponseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
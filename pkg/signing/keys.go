@@ -0,0 +1,115 @@
+// Package signing verifies detached manifest signatures (cosign simple
+// signing or JWS) against a configurable set of trusted public keys.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// KeySource resolves the set of public keys a Verifier should trust.
+type KeySource interface {
+	PublicKeys(ctx context.Context) ([]crypto.PublicKey, error)
+}
+
+// FileKeySource loads one or more PEM-encoded public keys from a file on
+// disk, re-read on every call so rotated keys take effect without a restart.
+type FileKeySource struct {
+	Path string
+}
+
+func (f FileKeySource) PublicKeys(ctx context.Context) ([]crypto.PublicKey, error) {
+	raw, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []crypto.PublicKey
+	rest := raw
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key in %s: %w", f.Path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// JWKSKeySource fetches a JSON Web Key Set from a URL, as published by a
+// KMS-backed signer or an external auth service.
+type JWKSKeySource struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+type jwks struct {
+	Keys []json.RawMessage `json:"keys"`
+}
+
+func (j JWKSKeySource) client() *http.Client {
+	if j.HTTPClient != nil {
+		return j.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (j JWKSKeySource) PublicKeys(ctx context.Context) ([]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make([]crypto.PublicKey, 0, len(set.Keys))
+	for _, raw := range set.Keys {
+		key, err := parseJWK(raw)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// KMSClient resolves a public key by id from a key-management service. It is
+// intentionally minimal so any backend (cloud KMS, vault transit, etc.) can
+// implement it.
+type KMSClient interface {
+	GetPublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error)
+}
+
+// KMSKeySource resolves its trusted keys from a KMSClient by id.
+type KMSKeySource struct {
+	Client KMSClient
+	KeyIDs []string
+}
+
+func (k KMSKeySource) PublicKeys(ctx context.Context) ([]crypto.PublicKey, error) {
+	keys := make([]crypto.PublicKey, 0, len(k.KeyIDs))
+	for _, id := range k.KeyIDs {
+		key, err := k.Client.GetPublicKey(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching key %q from KMS: %w", id, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,57 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/opencontainers/go-digest"
+)
+
+// CosignSimpleSigningMediaType is the media type of a detached cosign
+// "simple signing" signature blob.
+const CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+// Verifier checks a detached signature over a manifest digest against a
+// configured set of trusted keys.
+type Verifier struct {
+	Keys KeySource
+}
+
+// Verify returns nil if signature (a JWS compact token whose subject claim
+// is the manifest digest) was produced by one of the trusted keys. Raw
+// cosign simple-signing payloads are expected to be wrapped in a JWS by the
+// signing client. mediaType is not consulted: every signature is parsed as a
+// JWS.
+func (v *Verifier) Verify(ctx context.Context, subject digest.Digest, mediaType string, signature []byte) error {
+	keys, err := v.Keys.PublicKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("loading trusted keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no trusted keys configured")
+	}
+
+	var lastErr error
+	for _, key := range keys {
+		claims := jwt.MapClaims{}
+		token, err := jwt.ParseWithClaims(string(signature), claims, keyFunc(key))
+		if err != nil || !token.Valid {
+			lastErr = err
+			continue
+		}
+		if sub, _ := claims["sub"].(string); sub != subject.String() {
+			lastErr = fmt.Errorf("signature subject %q does not match manifest digest %q", sub, subject.String())
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("no trusted key produced a valid signature for %s: %w", subject, lastErr)
+}
+
+func keyFunc(key crypto.PublicKey) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		return key, nil
+	}
+}